@@ -0,0 +1,156 @@
+package logit
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	"golang.org/x/exp/slog"
+)
+
+// deferredEntry is a buffered record together with the group/attr history
+// that was active on the DeferredHandler clone that logged it.
+type deferredEntry struct {
+	record slog.Record
+	goas   []groupOrAttrs
+}
+
+// deferredState is shared by a DeferredHandler and every clone produced by
+// its WithAttrs/WithGroup, so records logged through any of them land in the
+// same ring buffer and see the same target once Flush runs.
+//
+// records is a true circular buffer once it reaches max: head is the index
+// of the oldest entry, and push overwrites it in place instead of
+// reallocating, so buffering at capacity is O(1) per record rather than
+// O(n).
+type deferredState struct {
+	mu      sync.Mutex
+	max     int
+	records []deferredEntry
+	head    int
+	target  slog.Handler // nil until Flush
+}
+
+// push appends e, dropping the oldest entry in place once len(records) == max.
+// max <= 0 means unbounded.
+func (s *deferredState) push(e deferredEntry) {
+	if s.max <= 0 || len(s.records) < s.max {
+		s.records = append(s.records, e)
+		return
+	}
+	s.records[s.head] = e
+	s.head = (s.head + 1) % s.max
+}
+
+// ordered returns the buffered entries oldest-first.
+func (s *deferredState) ordered() []deferredEntry {
+	if s.max <= 0 || len(s.records) < s.max {
+		return s.records
+	}
+	ordered := make([]deferredEntry, len(s.records))
+	for i := range ordered {
+		ordered[i] = s.records[(s.head+i)%s.max]
+	}
+	return ordered
+}
+
+// DeferredHandler buffers records logged before a real Handler is ready -
+// e.g. ones emitted via slog.Default() during an init() before config is
+// parsed - and replays them once Flush is called. After Flush it passes
+// every subsequent record straight through to the target.
+type DeferredHandler struct {
+	state *deferredState
+	goas  []groupOrAttrs
+}
+
+// NewDeferredHandler returns a DeferredHandler that retains at most max
+// records, dropping the oldest once full. max <= 0 means unbounded.
+func NewDeferredHandler(max int) *DeferredHandler {
+	return &DeferredHandler{state: &deferredState{max: max}}
+}
+
+// Enabled defers to the target's Enabled once Flush has run; before that,
+// everything is buffered, so every level is considered enabled.
+func (d *DeferredHandler) Enabled(ctx context.Context, level slog.Level) bool {
+	d.state.mu.Lock()
+	target := d.state.target
+	d.state.mu.Unlock()
+
+	if target != nil {
+		return target.Enabled(ctx, level)
+	}
+	return true
+}
+
+// Handle buffers r until Flush runs, after which it replays straight into the target.
+func (d *DeferredHandler) Handle(ctx context.Context, r slog.Record) error {
+	d.state.mu.Lock()
+	target := d.state.target
+	if target == nil {
+		d.state.push(deferredEntry{record: r.Clone(), goas: d.goas})
+		d.state.mu.Unlock()
+		return nil
+	}
+	d.state.mu.Unlock()
+
+	return replayInto(target, d.goas, ctx, r)
+}
+
+// WithAttrs returns a DeferredHandler with attrs appended to its group/attr history.
+func (d *DeferredHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	if len(attrs) == 0 {
+		return d
+	}
+	return d.withGroupOrAttrs(groupOrAttrs{attrs: attrs})
+}
+
+// WithGroup returns a DeferredHandler with name appended to its group/attr history.
+func (d *DeferredHandler) WithGroup(name string) slog.Handler {
+	if name == "" {
+		return d
+	}
+	return d.withGroupOrAttrs(groupOrAttrs{group: name})
+}
+
+// withGroupOrAttrs returns a clone of d with goa appended, sharing d's buffer.
+func (d *DeferredHandler) withGroupOrAttrs(goa groupOrAttrs) *DeferredHandler {
+	clone := &DeferredHandler{
+		state: d.state,
+		goas:  make([]groupOrAttrs, len(d.goas)+1),
+	}
+	copy(clone.goas, d.goas)
+	clone.goas[len(clone.goas)-1] = goa
+	return clone
+}
+
+// Flush replays every buffered record into target, reconstructing each
+// record's own group/attr context first, then switches the DeferredHandler
+// (and every clone of it) to pass records straight into target from then on.
+func (d *DeferredHandler) Flush(target slog.Handler) error {
+	d.state.mu.Lock()
+	defer d.state.mu.Unlock()
+
+	for _, e := range d.state.ordered() {
+		if err := replayInto(target, e.goas, context.Background(), e.record); err != nil {
+			return fmt.Errorf("cannot flush buffered record: %w", err)
+		}
+	}
+
+	d.state.records = nil
+	d.state.head = 0
+	d.state.target = target
+	return nil
+}
+
+// replayInto applies goas to target, in order, and hands r to the result.
+func replayInto(target slog.Handler, goas []groupOrAttrs, ctx context.Context, r slog.Record) error {
+	h := target
+	for _, goa := range goas {
+		if goa.attrs != nil {
+			h = h.WithAttrs(goa.attrs)
+		} else {
+			h = h.WithGroup(goa.group)
+		}
+	}
+	return h.Handle(ctx, r)
+}
@@ -0,0 +1,70 @@
+package logit
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"golang.org/x/exp/slog"
+)
+
+func TestDeferredHandler_FlushReplaysBufferedRecords(t *testing.T) {
+	deferred := NewDeferredHandler(10)
+
+	logger := slog.New(deferred).With("service", "logit").WithGroup("request")
+	logger.Info("starting up", "id", 1)
+	logger.Warn("slow init", "id", 2)
+
+	var buf bytes.Buffer
+	target := NewHandler(&buf, &Options{Level: slog.LevelInfo, Format: FormatLogfmt})
+
+	if err := deferred.Flush(target); err != nil {
+		t.Fatalf("Flush: %v", err)
+	}
+
+	out := buf.String()
+	for _, want := range []string{"starting up", "slow init", "service=logit", "request.id=1", "request.id=2"} {
+		if !strings.Contains(out, want) {
+			t.Errorf("flushed output %q does not contain %q", out, want)
+		}
+	}
+}
+
+func TestDeferredHandler_PassThroughAfterFlush(t *testing.T) {
+	deferred := NewDeferredHandler(10)
+
+	var buf bytes.Buffer
+	target := NewHandler(&buf, &Options{Level: slog.LevelInfo, Format: FormatLogfmt})
+	if err := deferred.Flush(target); err != nil {
+		t.Fatalf("Flush: %v", err)
+	}
+
+	slog.New(deferred).Info("after flush")
+
+	if !strings.Contains(buf.String(), "after flush") {
+		t.Errorf("expected post-flush record to pass through, got %q", buf.String())
+	}
+}
+
+func TestDeferredHandler_DropsOldestWhenFull(t *testing.T) {
+	deferred := NewDeferredHandler(2)
+	logger := slog.New(deferred)
+
+	logger.Info("first")
+	logger.Info("second")
+	logger.Info("third")
+
+	var buf bytes.Buffer
+	target := NewHandler(&buf, &Options{Level: slog.LevelInfo, Format: FormatLogfmt})
+	if err := deferred.Flush(target); err != nil {
+		t.Fatalf("Flush: %v", err)
+	}
+
+	out := buf.String()
+	if strings.Contains(out, "first") {
+		t.Errorf("expected oldest record to be dropped, got %q", out)
+	}
+	if !strings.Contains(out, "second") || !strings.Contains(out, "third") {
+		t.Errorf("expected the two most recent records to survive, got %q", out)
+	}
+}
@@ -0,0 +1,253 @@
+package logit
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"runtime"
+	"strconv"
+	"strings"
+	"time"
+
+	"golang.org/x/exp/slog"
+)
+
+// Format selects how a Handler renders a record.
+type Format int
+
+const (
+	// FormatPretty is the colorized multi-field "[time] LEVEL: message {...}" layout.
+	FormatPretty Format = iota
+	// FormatLogfmt renders "time=... level=... msg=... key=value" lines, uncolored.
+	FormatLogfmt
+	// FormatJSON renders a single-line JSON object compatible with slog.JSONHandler.
+	FormatJSON
+)
+
+// formatEnvVar lets operators flip output format without recompiling.
+const formatEnvVar = "LOGIT_FORMAT"
+
+// formatFromEnv reads formatEnvVar and reports whether it named a valid Format.
+func formatFromEnv() (Format, bool) {
+	switch strings.ToLower(os.Getenv(formatEnvVar)) {
+	case "pretty":
+		return FormatPretty, true
+	case "logfmt":
+		return FormatLogfmt, true
+	case "json":
+		return FormatJSON, true
+	default:
+		return FormatPretty, false
+	}
+}
+
+// field is a single attribute, already resolved and ReplaceAttr'd. key is a
+// dotted rendering of groups+name, used by the pretty and logfmt text
+// renderers; groups and name are kept apart so renderJSON can nest on real
+// group boundaries instead of reinterpreting dots that happen to appear in
+// name itself. val is name's already-stringified form; value is the
+// original slog.Value, used by renderJSON to keep native JSON types.
+type field struct {
+	key    string
+	groups []string
+	name   string
+	val    string
+	value  slog.Value
+}
+
+// timeLayout returns the time.Format layout to use for the record's
+// timestamp: opts.TimeFormat if set, else a default that depends on the
+// output format, since FormatJSON needs a layout a JSON consumer can parse
+// as a real timestamp rather than the pretty printer's bracketed clock.
+func (h *Handler) timeLayout() string {
+	if h.opts.TimeFormat != "" {
+		return h.opts.TimeFormat
+	}
+	if h.opts.Format == FormatJSON {
+		return time.RFC3339Nano
+	}
+	return timeFormat
+}
+
+// sourceInfo mirrors the shape slog.JSONHandler nests under "source".
+type sourceInfo struct {
+	Function string `json:"function"`
+	File     string `json:"file"`
+	Line     int    `json:"line"`
+}
+
+// sourceFrame resolves the record's program counter to its call frame, or
+// reports ok=false if AddSource is off or the record carries no PC.
+func (h *Handler) sourceFrame(r slog.Record) (frame runtime.Frame, ok bool) {
+	if !h.opts.AddSource || r.PC == 0 {
+		return runtime.Frame{}, false
+	}
+	frame, _ = runtime.CallersFrames([]uintptr{r.PC}).Next()
+	return frame, true
+}
+
+// source returns the short "file:line" the record was logged from, or "" if
+// AddSource is off or the record carries no program counter.
+func (h *Handler) source(r slog.Record) string {
+	frame, ok := h.sourceFrame(r)
+	if !ok {
+		return ""
+	}
+
+	file := frame.File
+	if i := strings.LastIndexByte(file, '/'); i >= 0 {
+		file = file[i+1:]
+	}
+
+	return fmt.Sprintf("%s:%d", file, frame.Line)
+}
+
+// jsonValue converts a slog.Value to the Go value json.Marshal should emit
+// for it, matching what slog.JSONHandler produces for the same Kind.
+func jsonValue(v slog.Value) any {
+	switch v.Kind() {
+	case slog.KindString:
+		return v.String()
+	case slog.KindInt64:
+		return v.Int64()
+	case slog.KindUint64:
+		return v.Uint64()
+	case slog.KindFloat64:
+		return v.Float64()
+	case slog.KindBool:
+		return v.Bool()
+	case slog.KindDuration:
+		return int64(v.Duration())
+	case slog.KindTime:
+		return v.Time()
+	default:
+		a := v.Any()
+		if err, ok := a.(error); ok {
+			if _, jm := a.(json.Marshaler); !jm {
+				return err.Error()
+			}
+		}
+		return a
+	}
+}
+
+// renderPretty renders r in the package's original colorized layout. Values
+// are left-padded to the widest value seen so far for their key, so columns
+// of repeated keys line up across a stream of similar records, and a key
+// present in opts.KeyColors is colored with its assigned code instead of the
+// default field color.
+func (h *Handler) renderPretty(r slog.Record, fields []field) string {
+	level := r.Level.String() + ":"
+	switch r.Level {
+	case slog.LevelInfo:
+		level = h.withColor(lightGreen, level)
+	case slog.LevelDebug:
+		level = h.withColor(blue, level)
+	case slog.LevelWarn:
+		level = h.withColor(yellow, level)
+	case slog.LevelError:
+		level = h.withColor(red, level)
+	}
+
+	pairs := make([]string, len(fields))
+	for i, f := range fields {
+		code := darkGray
+		if c, ok := h.opts.KeyColors[f.key]; ok {
+			code = c
+		}
+		pairs[i] = h.withColor(code, fmt.Sprintf("%s=%s", f.key, h.pad(f.key, f.val)))
+	}
+
+	parts := []string{h.withColor(lightGray, r.Time.Format(h.timeLayout())), level}
+	if src := h.source(r); src != "" {
+		parts = append(parts, h.withColor(darkGray, src))
+	}
+	parts = append(parts, h.withColor(white, r.Message), h.withColor(darkGray, "{")+strings.Join(pairs, " ")+h.withColor(darkGray, "}"))
+
+	return strings.Join(parts, " ") + "\n"
+}
+
+// renderLogfmt renders r as "time=... level=... msg=... key=value ...",
+// quoting values that contain a space, a double quote or '=' and padding the
+// rest to the widest value seen so far for their key.
+func (h *Handler) renderLogfmt(r slog.Record, fields []field) string {
+	pairs := []string{
+		"time=" + logfmtValue(r.Time.Format(h.timeLayout())),
+		"level=" + logfmtValue(r.Level.String()),
+	}
+	if src := h.source(r); src != "" {
+		pairs = append(pairs, "source="+logfmtValue(src))
+	}
+	pairs = append(pairs, "msg="+logfmtValue(r.Message))
+	for _, f := range fields {
+		if strings.ContainsAny(f.val, " \"=") {
+			pairs = append(pairs, f.key+"="+strconv.Quote(f.val))
+			continue
+		}
+		pairs = append(pairs, f.key+"="+h.pad(f.key, f.val))
+	}
+
+	return strings.Join(pairs, " ") + "\n"
+}
+
+// logfmtValue quotes s if it contains a space, a double quote or '=', so logfmt consumers
+// don't split it into multiple fields.
+func logfmtValue(s string) string {
+	if strings.ContainsAny(s, " \"=") {
+		return strconv.Quote(s)
+	}
+	return s
+}
+
+// pad left-pads val with spaces to the widest value seen so far for key,
+// then raises the recorded width for key to len(val) if val is wider. Must be
+// called with h.mut held.
+func (h *Handler) pad(key, val string) string {
+	width := h.fieldPadding[key]
+	if len(val) > width {
+		h.fieldPadding[key] = len(val)
+		return val
+	}
+	return strings.Repeat(" ", width-len(val)) + val
+}
+
+// renderJSON renders r as a single-line JSON object compatible with
+// slog.JSONHandler: attrs keep their native JSON type and group-valued attrs
+// nest the same way slog.JSONHandler nests them.
+func (h *Handler) renderJSON(r slog.Record, fields []field) string {
+	obj := make(map[string]any, len(fields)+3)
+	obj[slog.TimeKey] = r.Time.Format(h.timeLayout())
+	obj[slog.LevelKey] = r.Level.String()
+	obj[slog.MessageKey] = r.Message
+	if frame, ok := h.sourceFrame(r); ok {
+		obj[slog.SourceKey] = sourceInfo{Function: frame.Function, File: frame.File, Line: frame.Line}
+	}
+	for _, f := range fields {
+		setNestedPath(obj, f.groups, f.name, jsonValue(f.value))
+	}
+
+	b, err := json.Marshal(obj)
+	if err != nil {
+		return fmt.Sprintf(`{"%s":"error marshaling record: %s"}`+"\n", slog.MessageKey, err)
+	}
+
+	return string(b) + "\n"
+}
+
+// setNestedPath assigns val at name within the map reached by walking groups
+// from obj, creating intermediate maps for group boundaries as needed. It
+// nests only on the real WithGroup path, so a literal dot in an attribute's
+// own key (e.g. "http.status_code") stays a single flat key instead of being
+// reinterpreted as a group separator.
+func setNestedPath(obj map[string]any, groups []string, name string, val any) {
+	cur := obj
+	for _, g := range groups {
+		child, ok := cur[g].(map[string]any)
+		if !ok {
+			child = map[string]any{}
+			cur[g] = child
+		}
+		cur = child
+	}
+	cur[name] = val
+}
@@ -0,0 +1,118 @@
+package logit
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+
+	"golang.org/x/exp/slog"
+)
+
+func TestHandle_FormatLogfmt(t *testing.T) {
+	var buf bytes.Buffer
+	h := NewHandler(&buf, &Options{Level: slog.LevelInfo, Format: FormatLogfmt})
+
+	slog.New(h).Info("handled", "key", "value with spaces")
+
+	out := buf.String()
+	for _, want := range []string{`time=`, `level=INFO`, `msg=handled`, `key="value with spaces"`} {
+		if !strings.Contains(out, want) {
+			t.Errorf("logfmt output %q does not contain %q", out, want)
+		}
+	}
+	if strings.Contains(out, "\033[") {
+		t.Errorf("expected no ANSI codes in logfmt output, got %q", out)
+	}
+}
+
+func TestHandle_FormatJSON(t *testing.T) {
+	var buf bytes.Buffer
+	h := NewHandler(&buf, &Options{Level: slog.LevelInfo, Format: FormatJSON})
+
+	chained := h.WithAttrs([]slog.Attr{slog.Bool("ok", true)}).WithGroup("request").WithAttrs([]slog.Attr{slog.Int("id", 42)})
+	slog.New(chained).Info("handled")
+
+	var got map[string]any
+	if err := json.Unmarshal(buf.Bytes(), &got); err != nil {
+		t.Fatalf("output isn't valid JSON: %v\n%s", err, buf.String())
+	}
+	if got[slog.MessageKey] != "handled" {
+		t.Errorf("msg = %v, want handled", got[slog.MessageKey])
+	}
+	if got["ok"] != true {
+		t.Errorf("ok = %#v (%T), want native bool true", got["ok"], got["ok"])
+	}
+	req, ok := got["request"].(map[string]any)
+	if !ok {
+		t.Fatalf("expected nested \"request\" object, got %v", got["request"])
+	}
+	if req["id"] != float64(42) {
+		t.Errorf("request.id = %#v (%T), want native number 42", req["id"], req["id"])
+	}
+}
+
+func TestHandle_FormatJSONDottedKeyStaysFlat(t *testing.T) {
+	var buf bytes.Buffer
+	h := NewHandler(&buf, &Options{Level: slog.LevelInfo, Format: FormatJSON})
+
+	slog.New(h).Info("handled", "http.status_code", 200)
+
+	var got map[string]any
+	if err := json.Unmarshal(buf.Bytes(), &got); err != nil {
+		t.Fatalf("output isn't valid JSON: %v\n%s", err, buf.String())
+	}
+	if got["http.status_code"] != float64(200) {
+		t.Errorf(`got["http.status_code"] = %#v, want flat key 200`, got["http.status_code"])
+	}
+	if _, nested := got["http"]; nested {
+		t.Errorf("key containing a literal dot was nested under \"http\", got %v", got)
+	}
+}
+
+func TestHandle_FormatJSONGroupAndDottedKeyCoexist(t *testing.T) {
+	var buf bytes.Buffer
+	h := NewHandler(&buf, &Options{Level: slog.LevelInfo, Format: FormatJSON})
+
+	chained := h.WithAttrs([]slog.Attr{slog.String("request", "plain-scalar-value")})
+	slog.New(chained).Info("handled", "request.id", 42)
+
+	var got map[string]any
+	if err := json.Unmarshal(buf.Bytes(), &got); err != nil {
+		t.Fatalf("output isn't valid JSON: %v\n%s", err, buf.String())
+	}
+	if got["request"] != "plain-scalar-value" {
+		t.Errorf(`got["request"] = %#v, want untouched scalar "plain-scalar-value"`, got["request"])
+	}
+	if got["request.id"] != float64(42) {
+		t.Errorf(`got["request.id"] = %#v, want flat key 42`, got["request.id"])
+	}
+}
+
+func TestHandle_FormatJSONDefaultTimeIsParseable(t *testing.T) {
+	var buf bytes.Buffer
+	h := NewHandler(&buf, &Options{Level: slog.LevelInfo, Format: FormatJSON})
+
+	slog.New(h).Info("handled")
+
+	var got map[string]any
+	if err := json.Unmarshal(buf.Bytes(), &got); err != nil {
+		t.Fatalf("output isn't valid JSON: %v\n%s", err, buf.String())
+	}
+	ts, _ := got[slog.TimeKey].(string)
+	if _, err := time.Parse(time.RFC3339Nano, ts); err != nil {
+		t.Errorf("time %q is not RFC3339Nano-parseable: %v", ts, err)
+	}
+}
+
+func TestFormatFromEnv(t *testing.T) {
+	t.Setenv("LOGIT_FORMAT", "json")
+
+	var buf bytes.Buffer
+	h := NewHandler(&buf, &Options{Level: slog.LevelInfo})
+
+	if h.opts.Format != FormatJSON {
+		t.Errorf("expected LOGIT_FORMAT=json to select FormatJSON, got %v", h.opts.Format)
+	}
+}
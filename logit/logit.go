@@ -1,13 +1,14 @@
 package logit
 
 import (
-	"bytes"
 	"context"
-	"encoding/json"
 	"fmt"
+	"io"
+	"os"
 	"strconv"
 	"sync"
 
+	"github.com/mattn/go-isatty"
 	"golang.org/x/exp/slog"
 )
 
@@ -34,115 +35,249 @@ const (
 	white        = 97
 )
 
-// child: nested handler.
-// buffer: pipe child's output here.
-// mut: make buffer thread-safe across multiple Goroutines.
-type Handler struct {
-	child  slog.Handler
-	buffer *bytes.Buffer
-	mut    *sync.Mutex
+// Color controls whether Handler emits ANSI color codes.
+type Color int
+
+const (
+	// ColorAuto colors the output only when the destination writer is a terminal.
+	ColorAuto Color = iota
+	// ColorAlways always colors the output.
+	ColorAlways
+	// ColorNever never colors the output.
+	ColorNever
+)
+
+// Options configures a Handler. The zero value is valid and mirrors the
+// package's original hard-coded behavior: Info level, colorized when
+// writing to a terminal, default time format.
+type Options struct {
+	// Level is the minimum level the Handler is enabled for.
+	Level slog.Level
+
+	// AddSource reports the source file and line of the log call.
+	AddSource bool
+
+	// TimeFormat overrides the time.Format layout used for the record's timestamp.
+	// Defaults to "[15:04:05.000]" for FormatPretty and FormatLogfmt, and to
+	// time.RFC3339Nano for FormatJSON.
+	TimeFormat string
+
+	// Color controls ANSI colorization. Defaults to ColorAuto.
+	Color Color
+
+	// ReplaceAttr, if non-nil, is called on each user-supplied attribute
+	// before it's formatted, with the same signature as
+	// slog.HandlerOptions.ReplaceAttr. Unlike the stdlib handlers, it is not
+	// called for the built-in time/level/msg/source values: those are always
+	// rendered as-is.
+	ReplaceAttr func(groups []string, a slog.Attr) slog.Attr
+
+	// Format selects the output format. Defaults to FormatPretty, unless the
+	// LOGIT_FORMAT environment variable is set to "pretty", "logfmt" or "json"
+	// (case-insensitive), in which case it wins; other values are ignored.
+	Format Format
+
+	// KeyColors assigns a specific ANSI color code to well-known keys (e.g.
+	// err in red, duration in cyan) in the pretty format. Keys not listed
+	// fall back to the default field color.
+	KeyColors map[string]int
 }
 
-// withColor applies given color to a string.
-func withColor(code int, s string) string {
-	return fmt.Sprintf("\033[%sm%s%s", strconv.Itoa(code), s, reset)
+// groupOrAttrs records a single WithGroup or WithAttrs call in the order it
+// was made, so Handle can replay them to produce correctly prefixed keys.
+// Exactly one of group/attrs is set.
+type groupOrAttrs struct {
+	group string
+	attrs []slog.Attr
 }
 
-// Enabled returns true if child handler is enabled for specified level of logging.
-func (h *Handler) Enabled(ctx context.Context, level slog.Level) bool {
-	return h.child.Enabled(ctx, level)
+// Default mirrors the package's original behavior: pretty output to stdout at
+// Info level, colorized when stdout is a terminal. Kept for callers that
+// don't need custom Options.
+var Default = NewHandler(os.Stdout, nil)
+
+// opts: resolved configuration.
+// out: destination writer.
+// color: whether to emit ANSI codes, resolved once from opts.Color and out.
+// mut: guards writes to out and fieldPadding so concurrent goroutines produce
+// interleaving-free, consistently padded lines.
+// fieldPadding: widest value seen so far per key, so later lines line up in a column.
+// goas: ordered WithGroup/WithAttrs history accumulated by this Handler's ancestors.
+type Handler struct {
+	opts         Options
+	out          io.Writer
+	color        bool
+	mut          *sync.Mutex
+	fieldPadding map[string]int
+	goas         []groupOrAttrs
 }
 
-// WithAttrs returns Handler with specified attributes.
-func (h *Handler) WithAttrs(attrs []slog.Attr) slog.Handler {
-	return &Handler{
-		child:  h.child.WithAttrs(attrs),
-		buffer: h.buffer,
-		mut:    h.mut,
+// NewHandler returns a Handler that writes to w. A nil opts is equivalent to
+// the zero Options.
+func NewHandler(w io.Writer, opts *Options) *Handler {
+	o := Options{}
+	if opts != nil {
+		o = *opts
+	}
+	if f, ok := formatFromEnv(); ok {
+		o.Format = f
 	}
-}
 
-// Handle process record if it's enabled
-func (h *Handler) Handle(ctx context.Context, r slog.Record) error {
-	level := r.Level.String() + ":"
-	switch r.Level {
-	case slog.LevelInfo:
-		level = withColor(lightGreen, level)
-	case slog.LevelDebug:
-		level = withColor(blue, level)
-	case slog.LevelWarn:
-		level = withColor(yellow, level)
-	case slog.LevelError:
-		level = withColor(red, level)
+	return &Handler{
+		opts:         o,
+		out:          w,
+		color:        resolveColor(o.Color, w),
+		mut:          &sync.Mutex{},
+		fieldPadding: map[string]int{},
 	}
+}
 
-	childAttrs, err := h.extractChildAttrs(ctx, r)
-	if err != nil {
-		return err
+// resolveColor decides whether output to w should be colorized.
+func resolveColor(c Color, w io.Writer) bool {
+	switch c {
+	case ColorAlways:
+		return true
+	case ColorNever:
+		return false
+	default:
+		f, ok := w.(*os.File)
+		return ok && isatty.IsTerminal(f.Fd())
 	}
+}
 
-	bytes, err := json.MarshalIndent(childAttrs, "", " ")
-	if err != nil {
-		return err
+// withColor applies the given color to a string, unless color is disabled.
+func (h *Handler) withColor(code int, s string) string {
+	if !h.color {
+		return s
 	}
+	return fmt.Sprintf("\033[%sm%s%s", strconv.Itoa(code), s, reset)
+}
 
-	fmt.Println(withColor(lightGray, r.Time.Format(timeFormat)), level, withColor(white, r.Message), withColor(darkGray, string(bytes)))
+// Enabled returns true if the Handler is enabled for specified level of logging.
+func (h *Handler) Enabled(ctx context.Context, level slog.Level) bool {
+	return level >= h.opts.Level
+}
 
-	return nil
+// WithAttrs returns a Handler with attrs appended to its group/attr history.
+func (h *Handler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	if len(attrs) == 0 {
+		return h
+	}
+	return h.withGroupOrAttrs(groupOrAttrs{attrs: attrs})
 }
 
-// WithGroup returns Handler with named Group.
+// WithGroup returns a Handler with name appended to its group/attr history.
 func (h *Handler) WithGroup(name string) slog.Handler {
-	return &Handler{
-		child:  h.child.WithGroup(name),
-		buffer: h.buffer,
-		mut:    h.mut,
+	if name == "" {
+		return h
 	}
+	return h.withGroupOrAttrs(groupOrAttrs{group: name})
 }
 
-// extractChildAttrs takes child handler's attributes, writes it to a main Handlers and atteches to the main Handler's attributes.
-func (h *Handler) extractChildAttrs(ctx context.Context, r slog.Record) (map[string]any, error) {
-	h.mut.Lock()
-	defer func() {
-		h.buffer.Reset()
-		h.mut.Unlock()
-	}()
-
-	if err := h.child.Handle(ctx, r); err != nil {
-		return nil, fmt.Errorf("cannot handle child's attributes: %w", err)
+// withGroupOrAttrs returns a clone of h with goa appended.
+func (h *Handler) withGroupOrAttrs(goa groupOrAttrs) *Handler {
+	clone := &Handler{
+		opts:         h.opts,
+		out:          h.out,
+		color:        h.color,
+		mut:          h.mut,
+		fieldPadding: h.fieldPadding,
+		goas:         make([]groupOrAttrs, len(h.goas)+1),
 	}
+	copy(clone.goas, h.goas)
+	clone.goas[len(clone.goas)-1] = goa
+	return clone
+}
+
+// Handle formats r and writes it to h.out according to h.opts.Format.
+// Fields are collected before h.mut is taken; the lock is then held while
+// rendering and writing: field padding is updated as part of rendering, so it
+// must stay consistent with what's written.
+func (h *Handler) Handle(ctx context.Context, r slog.Record) error {
+	fields := h.collectFields(r)
+
+	h.mut.Lock()
+	defer h.mut.Unlock()
 
-	var childAttrs map[string]any
-	if err := json.Unmarshal(h.buffer.Bytes(), &childAttrs); err != nil {
-		return nil, fmt.Errorf("cannot unmarshall from buffer to map: %w", err)
+	var line string
+	switch h.opts.Format {
+	case FormatLogfmt:
+		line = h.renderLogfmt(r, fields)
+	case FormatJSON:
+		line = h.renderJSON(r, fields)
+	default:
+		line = h.renderPretty(r, fields)
 	}
 
-	return childAttrs, nil
+	_, err := io.WriteString(h.out, line)
+	return err
 }
 
-// emitDefault supresses child's TimeStamp and so on.
-func emitDefaults(next func([]string, slog.Attr) slog.Attr) func([]string, slog.Attr) slog.Attr {
-	return func(groups []string, a slog.Attr) slog.Attr {
-		if a.Key == slog.TimeKey || a.Key == slog.LevelKey || a.Key == slog.MessageKey {
-			return slog.Attr{}
+// collectFields walks h.goas and r's own attrs, applying ReplaceAttr and
+// tracking the real group path each attr sits under, shared by every output
+// format. prefix is a dotted rendering of that same path, used only by the
+// pretty/logfmt text renderers; groups is the actual []string path, used by
+// renderJSON to nest on real group boundaries rather than literal dots in a
+// key name.
+func (h *Handler) collectFields(r slog.Record) []field {
+	var fields []field
+	var groups []string
+	prefix := ""
+
+	goas := h.goas
+	// A group with no attrs under it (from this handler or the record) is invisible.
+	if r.NumAttrs() == 0 {
+		for len(goas) > 0 && goas[len(goas)-1].attrs == nil {
+			goas = goas[:len(goas)-1]
 		}
+	}
 
-		if next == nil {
-			return a
+	for _, goa := range goas {
+		if goa.attrs == nil {
+			prefix += goa.group + "."
+			groups = append(groups, goa.group)
+			continue
+		}
+		for _, a := range goa.attrs {
+			fields = h.appendAttr(fields, groups, prefix, a)
 		}
-		return next(groups, a)
 	}
-}
 
-func NewHandler(level slog.Level) *Handler {
-	buffer := &bytes.Buffer{}
+	r.Attrs(func(a slog.Attr) bool {
+		fields = h.appendAttr(fields, groups, prefix, a)
+		return true
+	})
 
-	return &Handler{
-		buffer: buffer,
-		child: slog.NewJSONHandler(buffer, &slog.HandlerOptions{
-			Level:       level,
-			ReplaceAttr: emitDefaults(slog.HandlerOptions{}.ReplaceAttr),
-		}),
-		mut: &sync.Mutex{},
+	return fields
+}
+
+// appendAttr formats a, prefixing its key with prefix, and appends it to fields.
+// Group-valued attrs are flattened recursively, extending prefix and groups
+// with their own name. groups is the real group path passed to
+// opts.ReplaceAttr, and stored on the resulting field for renderJSON.
+func (h *Handler) appendAttr(fields []field, groups []string, prefix string, a slog.Attr) []field {
+	if h.opts.ReplaceAttr != nil {
+		a = h.opts.ReplaceAttr(groups, a)
+	}
+	a.Value = a.Value.Resolve()
+	if a.Value.Kind() == slog.KindGroup {
+		if a.Key != "" {
+			prefix += a.Key + "."
+			// Grow into a fresh array so sibling fields already captured at
+			// this depth don't alias a slice that a later append could mutate.
+			next := make([]string, len(groups)+1)
+			copy(next, groups)
+			next[len(groups)] = a.Key
+			groups = next
+		}
+		for _, ga := range a.Value.Group() {
+			fields = h.appendAttr(fields, groups, prefix, ga)
+		}
+		return fields
+	}
+	if a.Equal(slog.Attr{}) {
+		return fields
 	}
+
+	return append(fields, field{key: prefix + a.Key, groups: groups, name: a.Key, val: a.Value.String(), value: a.Value})
 }
@@ -0,0 +1,76 @@
+package logit
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"testing"
+
+	"golang.org/x/exp/slog"
+)
+
+func TestHandle_WithGroupWithAttrsChain(t *testing.T) {
+	var buf bytes.Buffer
+	h := NewHandler(&buf, &Options{Level: slog.LevelInfo})
+
+	chained := h.WithAttrs([]slog.Attr{slog.String("service", "logit")}).
+		WithGroup("request").
+		WithAttrs([]slog.Attr{slog.Int("id", 42)}).
+		WithGroup("response").
+		WithAttrs([]slog.Attr{slog.Int("status", 200)})
+
+	logger := slog.New(chained)
+	logger.Info("handled", "took_ms", 12)
+
+	out := buf.String()
+	for _, want := range []string{"service=logit", "request.id=42", "request.response.status=200", "request.response.took_ms=12"} {
+		if !strings.Contains(out, want) {
+			t.Errorf("output %q does not contain %q", out, want)
+		}
+	}
+}
+
+func TestHandle_EmptyGroupDropped(t *testing.T) {
+	var buf bytes.Buffer
+	h := NewHandler(&buf, &Options{Level: slog.LevelInfo})
+
+	logger := slog.New(h.WithGroup("empty"))
+	logger.Info("no attrs here")
+
+	if strings.Contains(buf.String(), "empty.") {
+		t.Errorf("expected empty group to be dropped, got %q", buf.String())
+	}
+}
+
+func TestHandle_Enabled(t *testing.T) {
+	h := NewHandler(&bytes.Buffer{}, &Options{Level: slog.LevelWarn})
+
+	if h.Enabled(context.Background(), slog.LevelInfo) {
+		t.Error("expected Info to be disabled when level is Warn")
+	}
+	if !h.Enabled(context.Background(), slog.LevelError) {
+		t.Error("expected Error to be enabled when level is Warn")
+	}
+}
+
+func TestHandle_NoColorToNonTerminal(t *testing.T) {
+	var buf bytes.Buffer
+	h := NewHandler(&buf, &Options{Level: slog.LevelInfo})
+
+	slog.New(h).Info("plain")
+
+	if strings.Contains(buf.String(), "\033[") {
+		t.Errorf("expected no ANSI codes when writing to a non-terminal, got %q", buf.String())
+	}
+}
+
+func TestHandle_ColorAlways(t *testing.T) {
+	var buf bytes.Buffer
+	h := NewHandler(&buf, &Options{Level: slog.LevelInfo, Color: ColorAlways})
+
+	slog.New(h).Info("colored")
+
+	if !strings.Contains(buf.String(), "\033[") {
+		t.Errorf("expected ANSI codes with ColorAlways, got %q", buf.String())
+	}
+}
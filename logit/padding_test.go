@@ -0,0 +1,43 @@
+package logit
+
+import (
+	"bytes"
+	"strconv"
+	"strings"
+	"testing"
+
+	"golang.org/x/exp/slog"
+)
+
+func TestHandle_FieldPaddingAligns(t *testing.T) {
+	var buf bytes.Buffer
+	h := NewHandler(&buf, &Options{Level: slog.LevelInfo})
+	logger := slog.New(h)
+
+	logger.Info("first", "dur", "123456")
+	logger.Info("second", "dur", "7")
+
+	lines := strings.Split(strings.TrimRight(buf.String(), "\n"), "\n")
+	if len(lines) != 2 {
+		t.Fatalf("expected 2 lines, got %d: %q", len(lines), buf.String())
+	}
+	if !strings.Contains(lines[1], "dur=     7") {
+		t.Errorf("expected second line's dur to be padded to width 6, got %q", lines[1])
+	}
+}
+
+func TestHandle_KeyColors(t *testing.T) {
+	var buf bytes.Buffer
+	h := NewHandler(&buf, &Options{
+		Level:     slog.LevelInfo,
+		Color:     ColorAlways,
+		KeyColors: map[string]int{"err": red},
+	})
+
+	slog.New(h).Info("failed", "err", "boom")
+
+	want := "\033[" + strconv.Itoa(red) + "m" + "err=boom" + reset
+	if !strings.Contains(buf.String(), want) {
+		t.Errorf("output %q does not contain %q", buf.String(), want)
+	}
+}
@@ -0,0 +1,60 @@
+package logit
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"testing"
+
+	"golang.org/x/exp/slog"
+)
+
+func TestHandle_AddSource(t *testing.T) {
+	var buf bytes.Buffer
+	h := NewHandler(&buf, &Options{Level: slog.LevelInfo, AddSource: true})
+
+	logger := slog.New(h)
+	logger.Info("known line") // this line's number, see wantLine below
+	const wantLine = 18
+
+	want := fmt.Sprintf("source_test.go:%d", wantLine)
+	if !strings.Contains(buf.String(), want) {
+		t.Errorf("output %q does not contain %q", buf.String(), want)
+	}
+}
+
+func TestHandle_AddSourceJSONNested(t *testing.T) {
+	var buf bytes.Buffer
+	h := NewHandler(&buf, &Options{Level: slog.LevelInfo, AddSource: true, Format: FormatJSON})
+
+	logger := slog.New(h)
+	logger.Info("known line") // this line's number, see wantLine below
+	const wantLine = 32
+
+	var got map[string]any
+	if err := json.Unmarshal(buf.Bytes(), &got); err != nil {
+		t.Fatalf("output isn't valid JSON: %v\n%s", err, buf.String())
+	}
+	src, ok := got[slog.SourceKey].(map[string]any)
+	if !ok {
+		t.Fatalf("expected nested %q object, got %v", slog.SourceKey, got[slog.SourceKey])
+	}
+	if !strings.HasSuffix(src["file"].(string), "source_test.go") {
+		t.Errorf("source.file = %v, want suffix source_test.go", src["file"])
+	}
+	if src["line"] != float64(wantLine) {
+		t.Errorf("source.line = %v, want %d", src["line"], wantLine)
+	}
+}
+
+func TestHandle_AddSourceOff(t *testing.T) {
+	var buf bytes.Buffer
+	h := NewHandler(&buf, &Options{Level: slog.LevelInfo})
+
+	slog.New(h).Info("no source")
+
+	if strings.Contains(buf.String(), "source_test.go") {
+		t.Errorf("expected no source location when AddSource is false, got %q", buf.String())
+	}
+}